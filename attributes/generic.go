@@ -0,0 +1,124 @@
+/*
+ *
+ * Copyright 2022 gRPC authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package attributes
+
+import "reflect"
+
+// Key是某个类型V的强类型map key，底层存储依旧是Attributes.m这个map[interface{}]interface{}，
+// 只是把"这个key对应的值是什么类型"这件事从调用方的类型断言里挪到了Get/WithValue内部
+//
+// Tip 两个name相同但V不同的Key[V]，由于底层类型不一样，作为interface{}存进map时也是不同的key，
+// 不会互相覆盖
+type Key[V any] struct {
+	name string
+}
+
+// NewKey creates a new Key with the given name and type V.
+func NewKey[V any](name string) Key[V] {
+	return Key[V]{name: name}
+}
+
+// Get returns the value associated with k in a, and true if the key was
+// found in a. If it is not found, the zero value of V is returned instead
+// along with false.
+func Get[V any](a *Attributes, k Key[V]) (V, bool) {
+	var zero V
+	if a == nil {
+		return zero, false
+	}
+	v, ok := a.m[k]
+	if !ok {
+		return zero, false
+	}
+	tv, ok := v.(V)
+	if !ok {
+		return zero, false
+	}
+	return tv, true
+}
+
+// WithValue returns a new Attributes containing all key/value pairs in a,
+// plus the new key/value pair provided. The original a is not modified.
+func WithValue[V any](a *Attributes, k Key[V], v V) *Attributes {
+	if a == nil {
+		return New(k, v)
+	}
+	return a.WithValues(k, v)
+}
+
+// Equal reports whether a and b contain the same key/value pairs.
+// Values whose type is comparable (includes pointers, which are compared by
+// identity, not by what they point to) are compared with the == operator.
+// Values stored as a slice, map, or func directly (instead of behind a
+// pointer) are not comparable with == at all, so Equal instead compares
+// their underlying data pointer: two distinct slices/maps with identical
+// contents are NOT Equal unless they share the same backing array/map, which
+// matches how resolver.Address expects attribute changes to be detected.
+// Any other type that turns out to be uncomparable (e.g. a struct or array
+// with a slice/map/func field buried inside it) falls back to
+// reflect.DeepEqual, since there is no single backing pointer to compare by
+// identity in that case.
+func Equal(a, b *Attributes) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	if len(a.m) != len(b.m) {
+		return false
+	}
+	for k, v1 := range a.m {
+		v2, ok := b.m[k]
+		if !ok {
+			return false
+		}
+		if !valuesEqual(v1, v2) {
+			return false
+		}
+	}
+	return true
+}
+
+func valuesEqual(v1, v2 interface{}) bool {
+	rv1, rv2 := reflect.ValueOf(v1), reflect.ValueOf(v2)
+	if rv1.Kind() != rv2.Kind() {
+		return false
+	}
+	switch rv1.Kind() {
+	case reflect.Slice, reflect.Map, reflect.Func:
+		return identityEqual(rv1, rv2)
+	}
+	// == also panics for a struct/array whose Kind() isn't one of the three
+	// above but that embeds an uncomparable field (e.g. a struct wrapping a
+	// []string), since comparability depends on every field, not just the
+	// top-level Kind. Type().Comparable() is the only way to know in advance
+	// whether v1 == v2 is safe to evaluate at all.
+	if rv1.Type().Comparable() {
+		return v1 == v2
+	}
+	return reflect.DeepEqual(v1, v2)
+}
+
+// identityEqual比较slice/map/func底层的数据指针，而不是内容；
+// 这类kind本身就不支持==，之前误用reflect.DeepEqual会把"内容相同的两份独立slice"
+// 判定为Equal，但调用方（resolver/balancer）要的其实是"是不是同一份"
+func identityEqual(rv1, rv2 reflect.Value) bool {
+	if rv1.IsNil() || rv2.IsNil() {
+		return rv1.IsNil() == rv2.IsNil()
+	}
+	return rv1.Pointer() == rv2.Pointer()
+}
@@ -0,0 +1,127 @@
+/*
+ *
+ * Copyright 2022 gRPC authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package attributes
+
+import "testing"
+
+func TestGetWithValue(t *testing.T) {
+	k := NewKey[string]("k")
+	a := WithValue(New(), k, "v")
+	if got, ok := Get(a, k); !ok || got != "v" {
+		t.Fatalf("Get() = %q, %v; want \"v\", true", got, ok)
+	}
+}
+
+func TestGetMissing(t *testing.T) {
+	k := NewKey[int]("missing")
+	if got, ok := Get(New(), k); ok || got != 0 {
+		t.Fatalf("Get() = %v, %v; want 0, false", got, ok)
+	}
+}
+
+func TestGetNilAttributes(t *testing.T) {
+	k := NewKey[int]("k")
+	if got, ok := Get((*Attributes)(nil), k); ok || got != 0 {
+		t.Fatalf("Get() on nil *Attributes = %v, %v; want 0, false", got, ok)
+	}
+}
+
+func TestGetTypeMismatchDoesNotPanic(t *testing.T) {
+	strKey := NewKey[string]("shared-name")
+	intKey := NewKey[int]("shared-name")
+	a := WithValue(New(), strKey, "v")
+	if got, ok := Get(a, intKey); ok || got != 0 {
+		t.Fatalf("Get() with mismatched Key[V] = %v, %v; want 0, false", got, ok)
+	}
+}
+
+func TestWithValueDoesNotMutateOriginal(t *testing.T) {
+	k := NewKey[int]("k")
+	a := WithValue(New(), k, 1)
+	b := WithValue(a, k, 2)
+	if got, _ := Get(a, k); got != 1 {
+		t.Fatalf("original Attributes mutated: Get() = %v, want 1", got)
+	}
+	if got, _ := Get(b, k); got != 2 {
+		t.Fatalf("Get() on new Attributes = %v, want 2", got)
+	}
+}
+
+func TestEqualComparableValues(t *testing.T) {
+	a := New("k1", 1, "k2", "two")
+	b := New("k1", 1, "k2", "two")
+	if !Equal(a, b) {
+		t.Fatal("Equal() = false for identical comparable values, want true")
+	}
+	c := New("k1", 1, "k2", "three")
+	if Equal(a, c) {
+		t.Fatal("Equal() = true for differing comparable values, want false")
+	}
+}
+
+func TestEqualPointerIdentity(t *testing.T) {
+	p1, p2 := new(int), new(int)
+	*p1, *p2 = 5, 5
+	a := New("k", p1)
+	b := New("k", p1)
+	if !Equal(a, b) {
+		t.Fatal("Equal() = false for the same pointer, want true")
+	}
+	c := New("k", p2)
+	if Equal(a, c) {
+		t.Fatal("Equal() = true for two distinct pointers with equal pointees, want false")
+	}
+}
+
+func TestEqualSliceIdentityNotContents(t *testing.T) {
+	s := []string{"x", "y"}
+	a := New("k", s)
+	b := New("k", s)
+	if !Equal(a, b) {
+		t.Fatal("Equal() = false for the same backing slice, want true")
+	}
+	c := New("k", []string{"x", "y"})
+	if Equal(a, c) {
+		t.Fatal("Equal() = true for two distinct slices with equal contents, want false")
+	}
+}
+
+func TestEqualUncomparableStructFieldDoesNotPanic(t *testing.T) {
+	type Meta struct {
+		Tags []string
+	}
+	a := New("k", Meta{Tags: []string{"x", "y"}})
+	b := New("k", Meta{Tags: []string{"x", "y"}})
+	if !Equal(a, b) {
+		t.Fatal("Equal() = false for structs with equal uncomparable fields, want true")
+	}
+	c := New("k", Meta{Tags: []string{"x", "z"}})
+	if Equal(a, c) {
+		t.Fatal("Equal() = true for structs with differing uncomparable fields, want false")
+	}
+}
+
+func TestEqualNilAttributes(t *testing.T) {
+	if !Equal(nil, nil) {
+		t.Fatal("Equal(nil, nil) = false, want true")
+	}
+	if Equal(New(), nil) {
+		t.Fatal("Equal(New(), nil) = true, want false")
+	}
+}
@@ -0,0 +1,87 @@
+/*
+ *
+ * Copyright 2022 gRPC authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package connectivity
+
+import (
+	"context"
+	"sync"
+
+	"google.golang.org/grpc/grpclog"
+)
+
+// subscriberBufSize是每个订阅者channel的容量；状态机的转换很稀疏（人为触发，不是热路径），
+// 给一点缓冲就足够吸收短时间内的连续几次转换了
+const subscriberBufSize = 8
+
+// StateBroadcaster实现了Subscribe所需要的多订阅者分发逻辑，producer方（例如ClientConn/
+// SubConn这类真正驱动状态机的实现）只需要在每次状态变化时调用一次Notify
+//
+// Tip 这里没有把它做成Reporter的唯一实现，而是做成一个可以嵌入的小组件，
+// 是因为CurrentState/WaitForStateChange通常和连接本身的状态强绑定，交给producer自己实现更自然
+type StateBroadcaster struct {
+	mu   sync.Mutex
+	subs map[chan State]struct{}
+}
+
+// Subscribe registers a new subscriber and returns its channel. The
+// subscriber is automatically unregistered once ctx is done.
+func (b *StateBroadcaster) Subscribe(ctx context.Context) <-chan State {
+	b.mu.Lock()
+	if b.subs == nil {
+		b.subs = make(map[chan State]struct{})
+	}
+	ch := make(chan State, subscriberBufSize)
+	b.subs[ch] = struct{}{}
+	b.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		b.mu.Lock()
+		delete(b.subs, ch)
+		b.mu.Unlock()
+	}()
+	return ch
+}
+
+// Notify delivers s to every current subscriber. Repeated identical states
+// coming from the same producer should be coalesced by the caller (i.e.
+// only call Notify when the state actually transitions) so subscribers never
+// see the same state twice in a row. A subscriber that isn't keeping up has
+// its oldest buffered state dropped to make room, and a warning is logged
+// rather than blocking the producer.
+func (b *StateBroadcaster) Notify(s State) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for ch := range b.subs {
+		select {
+		case ch <- s:
+		default:
+			select {
+			case <-ch:
+				grpclog.Warning("connectivity: dropping oldest state notification for a slow Subscribe subscriber")
+			default:
+			}
+			select {
+			case ch <- s:
+			default:
+				grpclog.Warning("connectivity: dropping state notification for a slow Subscribe subscriber")
+			}
+		}
+	}
+}
@@ -0,0 +1,129 @@
+/*
+ *
+ * Copyright 2022 gRPC authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package connectivity
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// fakeReporter implements Subscriber on top of a StateBroadcaster, the way a
+// real ClientConn/SubConn would.
+type fakeReporter struct {
+	StateBroadcaster
+	state State
+}
+
+func (f *fakeReporter) CurrentState() State { return f.state }
+
+func (f *fakeReporter) WaitForStateChange(ctx context.Context, source State) bool {
+	// Not exercised by the Subscribe-based tests below.
+	<-ctx.Done()
+	return false
+}
+
+func (f *fakeReporter) transitionTo(s State) {
+	f.state = s
+	f.Notify(s)
+}
+
+func TestBroadcasterDeliversTransitionsInOrder(t *testing.T) {
+	f := &fakeReporter{state: Idle}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ch := f.Subscribe(ctx)
+
+	want := []State{Connecting, Ready, TransientFailure}
+	for _, s := range want {
+		f.transitionTo(s)
+	}
+	for _, s := range want {
+		select {
+		case got := <-ch:
+			if got != s {
+				t.Fatalf("Subscribe channel delivered %v, want %v", got, s)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("timed out waiting for state %v", s)
+		}
+	}
+}
+
+func TestWaitForStateAlreadyThere(t *testing.T) {
+	f := &fakeReporter{state: Ready}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := WaitForState(ctx, f, Ready); err != nil {
+		t.Fatalf("WaitForState() = %v, want nil", err)
+	}
+}
+
+func TestWaitForStateObservesLaterTransition(t *testing.T) {
+	f := &fakeReporter{state: Idle}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() { done <- WaitForState(ctx, f, Ready) }()
+
+	// Give WaitForState a chance to Subscribe before the transition happens.
+	time.Sleep(10 * time.Millisecond)
+	f.transitionTo(Connecting)
+	f.transitionTo(Ready)
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("WaitForState() = %v, want nil", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("WaitForState did not return after reaching target state")
+	}
+}
+
+func TestWaitForStateContextDone(t *testing.T) {
+	f := &fakeReporter{state: Idle}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := WaitForState(ctx, f, Ready); err == nil {
+		t.Fatal("WaitForState() = nil for an already-done context, want an error")
+	}
+}
+
+func TestBroadcasterDropsSlowSubscriberWithoutBlocking(t *testing.T) {
+	f := &fakeReporter{state: Idle}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	_ = f.Subscribe(ctx) // never drained
+
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < subscriberBufSize*4; i++ {
+			f.transitionTo(State(i % int(Shutdown+1)))
+		}
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Notify blocked on a slow subscriber instead of dropping its oldest state")
+	}
+}
@@ -63,3 +63,61 @@ type Reporter interface {
 	CurrentState() State
 	WaitForStateChange(context.Context, State) bool
 }
+
+// Subscriber扩展了Reporter，提供了完整状态机的订阅通道；WaitForStateChange只能告诉调用方
+// "状态和source不一样了"，看不到中间经过的每一个状态，实现readiness-gate之类的逻辑时
+// 只能靠不断轮询source来拼凑出完整的转换过程
+type Subscriber interface {
+	Reporter
+
+	// Subscribe returns a channel on which every state transition is
+	// delivered, in order, until ctx is done. The channel is not closed when
+	// ctx is done; callers should stop reading from it at that point.
+	Subscribe(ctx context.Context) <-chan State
+}
+
+// WaitForState blocks until r reaches target, or returns ctx.Err() if ctx is
+// done first. If r implements Subscriber, the wait is done via Subscribe so
+// no intermediate transition is missed; otherwise it falls back to polling
+// with WaitForStateChange.
+func WaitForState(ctx context.Context, r Reporter, target State) error {
+	if s, ok := r.(Subscriber); ok {
+		return waitForStateViaSubscribe(ctx, s, target)
+	}
+	return waitForStateViaPoll(ctx, r, target)
+}
+
+// Subscribe必须先于CurrentState()调用：如果反过来，CurrentState()读完之后、
+// Subscribe()真正挂上订阅者之前这段窗口里发生的状态转换就会被错过，
+// 调用方会一直等到ctx结束，而不是在target出现过之后马上返回
+func waitForStateViaSubscribe(ctx context.Context, s Subscriber, target State) error {
+	ch := s.Subscribe(ctx)
+	if s.CurrentState() == target {
+		return nil
+	}
+	for {
+		select {
+		case st, ok := <-ch:
+			if !ok {
+				return ctx.Err()
+			}
+			if st == target {
+				return nil
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func waitForStateViaPoll(ctx context.Context, r Reporter, target State) error {
+	for {
+		current := r.CurrentState()
+		if current == target {
+			return nil
+		}
+		if !r.WaitForStateChange(ctx, current) {
+			return ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,236 @@
+/*
+ *
+ * Copyright 2020 gRPC authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package grpclog
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"google.golang.org/grpc/internal/grpclog"
+)
+
+// componentData代表某个子系统（例如transport、channelz）的logger，它自己的日志级别存在componentLevel里
+// 没有单独配置时，level()回退到globalLevel()，即跟随全局环境变量的设置
+type componentData struct {
+	component string
+}
+
+var (
+	componentMu    sync.RWMutex
+	componentCache = map[string]*componentData{}
+	componentLevel = map[string]struct{ sev, v int }{}
+)
+
+// 每个打印方法都会在消息前面加上[component-name]前缀
+// depth+1是因为这里比直接调用InfoDepth多了一层componentData包装
+func (c *componentData) InfoDepth(depth int, args ...interface{}) {
+	if !c.enabled(infoLog) {
+		return
+	}
+	args = append([]interface{}{"[" + c.component + "]"}, args...)
+	c.logDepth(depth+1, infoLog, args...)
+}
+
+func (c *componentData) WarningDepth(depth int, args ...interface{}) {
+	if !c.enabled(warningLog) {
+		return
+	}
+	args = append([]interface{}{"[" + c.component + "]"}, args...)
+	c.logDepth(depth+1, warningLog, args...)
+}
+
+func (c *componentData) ErrorDepth(depth int, args ...interface{}) {
+	if !c.enabled(errorLog) {
+		return
+	}
+	args = append([]interface{}{"[" + c.component + "]"}, args...)
+	c.logDepth(depth+1, errorLog, args...)
+}
+
+func (c *componentData) FatalDepth(depth int, args ...interface{}) {
+	args = append([]interface{}{"[" + c.component + "]"}, args...)
+	c.logDepth(depth+1, fatalLog, args...)
+}
+
+// logDepth把自己这个组件算出来的sev交给底层Logger，而不是再调用包级别的
+// InfoDepth/WarningDepth/ErrorDepth/FatalDepth——那几个函数是按*全局*Logger的阈值
+// 过滤的，如果某个组件单独配置了比全局更详细的级别（比如全局ERROR、组件单独开到INFO），
+// 消息在c.enabled()这一关已经放行了，经过包级别函数时还是会被全局阈值再拦一次。
+// 装进来的是内置的loggerT/structuredLogger时，它们都实现了severityBypassLogger，
+// 可以直接按c算出来的lvl写；换成了外部自定义的LoggerV2，就没法绕过它自己的过滤逻辑，
+// 只能退化成走包级别函数，尽量不丢失日志
+// fatalLog不走logSeverityDepth（它只写不退出），而是走FatalDepth，保证写完之后os.Exit(1)
+func (c *componentData) logDepth(depth, lvl int, args ...interface{}) {
+	if sb, ok := grpclog.Logger.(severityBypassLogger); ok && lvl != fatalLog {
+		sb.logSeverityDepth(depth+1, lvl, args...)
+		return
+	}
+	switch lvl {
+	case infoLog:
+		InfoDepth(depth+1, args...)
+	case warningLog:
+		WarningDepth(depth+1, args...)
+	case errorLog:
+		ErrorDepth(depth+1, args...)
+	case fatalLog:
+		FatalDepth(depth+1, args...)
+	}
+}
+
+func (c *componentData) Info(args ...interface{})  { c.InfoDepth(1, args...) }
+func (c *componentData) Infoln(args ...interface{}) { c.InfoDepth(1, args...) }
+
+func (c *componentData) Infof(format string, args ...interface{}) {
+	c.InfoDepth(1, fmt.Sprintf(format, args...))
+}
+
+func (c *componentData) Warning(args ...interface{})  { c.WarningDepth(1, args...) }
+func (c *componentData) Warningln(args ...interface{}) { c.WarningDepth(1, args...) }
+
+func (c *componentData) Warningf(format string, args ...interface{}) {
+	c.WarningDepth(1, fmt.Sprintf(format, args...))
+}
+
+func (c *componentData) Error(args ...interface{})  { c.ErrorDepth(1, args...) }
+func (c *componentData) Errorln(args ...interface{}) { c.ErrorDepth(1, args...) }
+
+func (c *componentData) Errorf(format string, args ...interface{}) {
+	c.ErrorDepth(1, fmt.Sprintf(format, args...))
+}
+
+func (c *componentData) Fatal(args ...interface{})  { c.FatalDepth(1, args...) }
+func (c *componentData) Fatalln(args ...interface{}) { c.FatalDepth(1, args...) }
+
+func (c *componentData) Fatalf(format string, args ...interface{}) {
+	c.FatalDepth(1, fmt.Sprintf(format, args...))
+}
+
+func (c *componentData) V(l int) bool {
+	sev, v := c.level()
+	if sev > infoLog {
+		// 该组件配置的最低severity高于Info，意味着Info/V相关的打印都被关闭
+		return false
+	}
+	return l <= v
+}
+
+// enabled返回sev这个级别是否达到了该组件生效的severity阈值
+func (c *componentData) enabled(sev int) bool {
+	got, _ := c.level()
+	return sev >= got
+}
+
+// level返回该组件生效的severity+verbosity，没有单独配置时回退到全局的V()/severity
+func (c *componentData) level() (sev, v int) {
+	componentMu.RLock()
+	l, ok := componentLevel[c.component]
+	componentMu.RUnlock()
+	if ok {
+		return l.sev, l.v
+	}
+	return globalLevel()
+}
+
+// Component创建一个带有[component-name]前缀的DepthLoggerV2，相同name复用同一个实例
+// GRPC_GO_LOG_SEVERITY_LEVEL_<COMPONENT>/GRPC_GO_LOG_VERBOSITY_LEVEL_<COMPONENT>用于单独配置
+// 这两个环境变量中的COMPONENT是name转大写，并把非字母数字字符替换为下划线后的结果
+func Component(component string) DepthLoggerV2 {
+	componentMu.Lock()
+	defer componentMu.Unlock()
+	if c, ok := componentCache[component]; ok {
+		return c
+	}
+	c := &componentData{component: component}
+	componentCache[component] = c
+	// SetComponentLevel的文档说它优先级高于环境变量，如果调用方在第一次Component(name)
+	// 之前就已经调用过SetComponentLevel，这里不能因为env也设置了就覆盖掉那个程序化配置——
+	// 否则"优先级更高"就变成了"谁后调用谁生效"，和调用顺序绑在了一起
+	if _, exists := componentLevel[component]; !exists {
+		if sev, v, ok := componentLevelFromEnv(component); ok {
+			componentLevel[component] = struct{ sev, v int }{sev, v}
+		}
+	}
+	return c
+}
+
+// SetComponentLevel用于程序化地重新配置某个组件的日志级别，优先级高于环境变量
+func SetComponentLevel(name string, sev, v int) {
+	componentMu.Lock()
+	defer componentMu.Unlock()
+	componentLevel[name] = struct{ sev, v int }{sev, v}
+}
+
+func componentLevelFromEnv(component string) (sev, v int, ok bool) {
+	suffix := envSuffix(component)
+	sev, v = -1, -1
+	if s := os.Getenv("GRPC_GO_LOG_SEVERITY_LEVEL_" + suffix); s != "" {
+		sev = severityFromString(s)
+		ok = true
+	}
+	if s := os.Getenv("GRPC_GO_LOG_VERBOSITY_LEVEL_" + suffix); s != "" {
+		if vl, err := strconv.Atoi(s); err == nil {
+			v = vl
+			ok = true
+		}
+	}
+	// 任何一个没有单独配置，都回退到对应的全局设置，而不是停留在零值
+	gsev, gv := globalLevel()
+	if sev == -1 {
+		sev = gsev
+	}
+	if v == -1 {
+		v = gv
+	}
+	return sev, v, ok
+}
+
+func envSuffix(component string) string {
+	upper := strings.ToUpper(component)
+	return strings.Map(func(r rune) rune {
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '_'
+	}, upper)
+}
+
+// globalLevel从全局环境变量推出默认的severity/verbosity，作为组件未单独配置时的兜底
+func globalLevel() (sev, v int) {
+	sev = severityFromString(os.Getenv("GRPC_GO_LOG_SEVERITY_LEVEL"))
+	if vl, err := strconv.Atoi(os.Getenv("GRPC_GO_LOG_VERBOSITY_LEVEL")); err == nil {
+		v = vl
+	}
+	return sev, v
+}
+
+func severityFromString(s string) int {
+	switch s {
+	case "WARNING", "warning":
+		return warningLog
+	case "INFO", "info":
+		return infoLog
+	case "", "ERROR", "error":
+		return errorLog
+	default:
+		return errorLog
+	}
+}
@@ -0,0 +1,117 @@
+/*
+ *
+ * Copyright 2020 gRPC authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package grpclog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc/internal/grpclog"
+)
+
+func TestEnvSuffix(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"transport", "TRANSPORT"},
+		{"xds-client", "XDS_CLIENT"},
+		{"a.b.c", "A_B_C"},
+	}
+	for _, tt := range tests {
+		if got := envSuffix(tt.in); got != tt.want {
+			t.Errorf("envSuffix(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestComponentReturnsSameInstance(t *testing.T) {
+	c1 := Component("same-component-test")
+	c2 := Component("same-component-test")
+	if c1 != c2 {
+		t.Fatal("Component() called twice with the same name returned different instances")
+	}
+}
+
+func TestComponentLevelFromEnvFallsBackToGlobal(t *testing.T) {
+	t.Setenv("GRPC_GO_LOG_SEVERITY_LEVEL", "WARNING")
+	t.Setenv("GRPC_GO_LOG_VERBOSITY_LEVEL", "3")
+	t.Setenv("GRPC_GO_LOG_SEVERITY_LEVEL_FOO_COMPONENT", "INFO")
+	t.Setenv("GRPC_GO_LOG_VERBOSITY_LEVEL_FOO_COMPONENT", "")
+
+	sev, v, ok := componentLevelFromEnv("foo-component")
+	if !ok {
+		t.Fatal("componentLevelFromEnv() ok = false, want true (severity override is set)")
+	}
+	if sev != infoLog {
+		t.Errorf("sev = %v, want infoLog (from the per-component override)", sev)
+	}
+	if v != 3 {
+		t.Errorf("v = %v, want 3 (falls back to GRPC_GO_LOG_VERBOSITY_LEVEL since no per-component override is set)", v)
+	}
+}
+
+func TestComponentCanBeLouderThanGlobal(t *testing.T) {
+	var buf bytes.Buffer
+	l := newLoggerTWithSeverity(&buf, &buf, &buf, errorLog, 0)
+	prev := grpclog.Logger
+	SetLoggerV2(l)
+	defer SetLoggerV2(prev)
+
+	SetComponentLevel("louder-than-global-test", infoLog, 0)
+	Component("louder-than-global-test").Info("hello")
+
+	if !strings.Contains(buf.String(), "hello") {
+		t.Fatalf("component configured more verbose (infoLog) than the global ERROR threshold produced no output: %q", buf.String())
+	}
+}
+
+func TestSetComponentLevelBeforeComponentTakesPrecedenceOverEnv(t *testing.T) {
+	t.Setenv("GRPC_GO_LOG_SEVERITY_LEVEL_PRE_SET_TEST", "ERROR")
+	SetComponentLevel("pre-set-test", infoLog, 5)
+
+	// Component() must not let the env-derived level clobber a level that was
+	// already set programmatically before this, the first, call.
+	Component("pre-set-test")
+
+	componentMu.RLock()
+	lvl, ok := componentLevel["pre-set-test"]
+	componentMu.RUnlock()
+	if !ok {
+		t.Fatal("componentLevel entry missing after Component()")
+	}
+	if lvl.sev != infoLog || lvl.v != 5 {
+		t.Errorf("level = %+v, want {sev:infoLog v:5} (SetComponentLevel called before Component() must win over env)", lvl)
+	}
+}
+
+func TestSetComponentLevelOverridesEnv(t *testing.T) {
+	t.Setenv("GRPC_GO_LOG_SEVERITY_LEVEL_OVERRIDE_COMPONENT_TEST", "INFO")
+	// The first Component() call records the env-derived level (infoLog);
+	// SetComponentLevel afterwards must replace it.
+	Component("override-component-test")
+	SetComponentLevel("override-component-test", errorLog, 7)
+	componentMu.RLock()
+	lvl, ok := componentLevel["override-component-test"]
+	componentMu.RUnlock()
+	if !ok {
+		t.Fatal("SetComponentLevel did not record a level")
+	}
+	if lvl.sev != errorLog || lvl.v != 7 {
+		t.Errorf("level = %+v, want {sev:errorLog v:7}", lvl)
+	}
+}
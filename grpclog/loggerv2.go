@@ -19,8 +19,8 @@
 package grpclog
 
 import (
+	"fmt"
 	"io"
-	"io/ioutil"
 	"log"
 	"os"
 	"strconv"
@@ -72,6 +72,11 @@ type loggerT struct {
 	// log为官方的log包
 	m []*log.Logger
 	v int
+	// sev是最低展示的severity；NewLoggerV2/NewLoggerV2WithVerbosity的调用方一直是靠
+	// 把某个writer设成io.Discard来做过滤的，sev对它们始终是零值infoLog，不会额外拦截
+	// 任何东西。只有newLoggerV2()构造的那个默认实例会把sev设成真正的阈值，这样
+	// Component()才能在某个组件单独配置了更高verbosity时，绕开这个全局阈值直接输出
+	sev int
 }
 
 // 定义了3个Writer，没有Fatal，是因为Fatal其实是error+os.Exit
@@ -83,74 +88,117 @@ func NewLoggerV2(infoW, warningW, errorW io.Writer) LoggerV2 {
 // 定义了四个日志，其中高等级的包含低等级的打印
 // Tip io包中的MultiWriter和MultiReader，是一个很有用的工具，你可以看看其中的实现
 func NewLoggerV2WithVerbosity(infoW, warningW, errorW io.Writer, v int) LoggerV2 {
+	return newLoggerTWithSeverity(infoW, warningW, errorW, infoLog, v)
+}
+
+// newLoggerTWithSeverity和NewLoggerV2WithVerbosity的区别只在于多了一个sev参数：
+// newLoggerV2()用它来让默认实例也具备"运行时按阈值过滤"的能力，而不是像过去那样
+// 把达不到阈值的writer直接替换成io.Discard——后者一旦构造完成就无法被任何人（包括
+// Component()）重新打开，前者只是一个可以被绕过的动态判断
+func newLoggerTWithSeverity(infoW, warningW, errorW io.Writer, sev, v int) *loggerT {
 	var m []*log.Logger
 	m = append(m, log.New(infoW, severityName[infoLog]+": ", log.LstdFlags))
 	m = append(m, log.New(io.MultiWriter(infoW, warningW), severityName[warningLog]+": ", log.LstdFlags))
 	ew := io.MultiWriter(infoW, warningW, errorW)
 	m = append(m, log.New(ew, severityName[errorLog]+": ", log.LstdFlags))
 	m = append(m, log.New(ew, severityName[fatalLog]+": ", log.LstdFlags))
-	return &loggerT{m: m, v: v}
+	return &loggerT{m: m, v: v, sev: sev}
 }
 
 // 默认的日志实现
 func newLoggerV2() LoggerV2 {
-	// 先初始化为不写
-	errorW := ioutil.Discard
-	warningW := ioutil.Discard
-	infoW := ioutil.Discard
-
 	// 根据环境变量的设置，默认为error
 	logLevel := os.Getenv("GRPC_GO_LOG_SEVERITY_LEVEL")
-	switch logLevel {
-	case "", "ERROR", "error":
-		errorW = os.Stderr
-	case "WARNING", "warning":
-		warningW = os.Stderr
-	case "INFO", "info":
-		infoW = os.Stderr
-	}
+	sev := severityFromString(logLevel)
 
 	var v int
 	vLevel := os.Getenv("GRPC_GO_LOG_VERBOSITY_LEVEL")
 	if vl, err := strconv.Atoi(vLevel); err == nil {
 		v = vl
 	}
-	return NewLoggerV2WithVerbosity(infoW, warningW, errorW, v)
+
+	// GRPC_GO_LOG_FORMAT=json切到日志聚合端友好的单行JSON输出，
+	// GRPC_GO_LOG_ASYNC=1再叠加一层有界channel，避免同步log.Logger.Print卡住RPC热路径
+	// severityFromString(logLevel)和下面的sev是同一个GRPC_GO_LOG_SEVERITY_LEVEL，
+	// 必须带进structuredLogger，否则JSON模式会无视这个环境变量、把Info往上全部打出来
+	if os.Getenv("GRPC_GO_LOG_FORMAT") == "json" {
+		async := os.Getenv("GRPC_GO_LOG_ASYNC") == "1"
+		return newStructuredLogger(os.Stderr, sev, v, async)
+	}
+	// infoW指向os.Stderr，所有级别都会经由MultiWriter写到它，且每条只写一次；warningW/errorW
+	// 设成io.Discard，否则同一条warning/error会经MultiWriter重复写两三次到os.Stderr。
+	// 真正按severity过滤交给上面的sev字段在调用时动态判断，
+	// 不再像过去那样把达不到全局阈值的writer直接焊死成io.Discard——那样component.go
+	// 里单独配置了更高verbosity的组件，消息最终还是会经由这里的writer被悄悄丢弃
+	return newLoggerTWithSeverity(os.Stderr, io.Discard, io.Discard, sev, v)
+}
+
+// enabled返回lvl这个级别是否达到了g.sev这个阈值；NewLoggerV2WithVerbosity构造的实例
+// g.sev恒为infoLog，这里永远为true，过滤完全交给调用方传入的writer（可能是io.Discard）
+func (g *loggerT) enabled(lvl int) bool {
+	return lvl >= g.sev
 }
 
 func (g *loggerT) Info(args ...interface{}) {
+	if !g.enabled(infoLog) {
+		return
+	}
 	g.m[infoLog].Print(args...)
 }
 
 func (g *loggerT) Infoln(args ...interface{}) {
+	if !g.enabled(infoLog) {
+		return
+	}
 	g.m[infoLog].Println(args...)
 }
 
 func (g *loggerT) Infof(format string, args ...interface{}) {
+	if !g.enabled(infoLog) {
+		return
+	}
 	g.m[infoLog].Printf(format, args...)
 }
 
 func (g *loggerT) Warning(args ...interface{}) {
+	if !g.enabled(warningLog) {
+		return
+	}
 	g.m[warningLog].Print(args...)
 }
 
 func (g *loggerT) Warningln(args ...interface{}) {
+	if !g.enabled(warningLog) {
+		return
+	}
 	g.m[warningLog].Println(args...)
 }
 
 func (g *loggerT) Warningf(format string, args ...interface{}) {
+	if !g.enabled(warningLog) {
+		return
+	}
 	g.m[warningLog].Printf(format, args...)
 }
 
 func (g *loggerT) Error(args ...interface{}) {
+	if !g.enabled(errorLog) {
+		return
+	}
 	g.m[errorLog].Print(args...)
 }
 
 func (g *loggerT) Errorln(args ...interface{}) {
+	if !g.enabled(errorLog) {
+		return
+	}
 	g.m[errorLog].Println(args...)
 }
 
 func (g *loggerT) Errorf(format string, args ...interface{}) {
+	if !g.enabled(errorLog) {
+		return
+	}
 	g.m[errorLog].Printf(format, args...)
 }
 
@@ -170,6 +218,41 @@ func (g *loggerT) V(l int) bool {
 	return l <= g.v
 }
 
+// calldepth+2是因为调用链多了Output这一层，再加上DepthLogger调用者本身那一层
+// Tip log.Logger.Output的第一个参数就是calldepth，用来让%file:%line定位到真正的调用处
+func (g *loggerT) InfoDepth(depth int, args ...interface{}) {
+	if !g.enabled(infoLog) {
+		return
+	}
+	g.m[infoLog].Output(2+depth, fmt.Sprint(args...))
+}
+
+func (g *loggerT) WarningDepth(depth int, args ...interface{}) {
+	if !g.enabled(warningLog) {
+		return
+	}
+	g.m[warningLog].Output(2+depth, fmt.Sprint(args...))
+}
+
+func (g *loggerT) ErrorDepth(depth int, args ...interface{}) {
+	if !g.enabled(errorLog) {
+		return
+	}
+	g.m[errorLog].Output(2+depth, fmt.Sprint(args...))
+}
+
+func (g *loggerT) FatalDepth(depth int, args ...interface{}) {
+	g.m[fatalLog].Output(2+depth, fmt.Sprint(args...))
+	os.Exit(1)
+}
+
+// logSeverityDepth绕开g.sev这个实例级别的阈值，直接按调用方指定的lvl写。
+// Component()靠这个方法实现"单个组件比全局更详细"：组件自己的severity检查已经
+// 通过了c.enabled()这一关，如果再经过InfoDepth/WarningDepth/ErrorDepth的g.sev检查，
+// 全局阈值更严格时这条消息还是会在这里被第二次拦下来
+func (g *loggerT) logSeverityDepth(depth, lvl int, args ...interface{}) {
+	g.m[lvl].Output(2+depth, fmt.Sprint(args...))
+}
 
 // 这个接口实现了打印了stack depth，也就是栈深度
 type DepthLoggerV2 interface {
@@ -178,3 +261,99 @@ type DepthLoggerV2 interface {
 	ErrorDepth(depth int, args ...interface{})
 	FatalDepth(depth int, args ...interface{})
 }
+
+// severityBypassLogger是loggerT/structuredLogger这两个内置实现才有的能力：按调用方
+// 指定的lvl直接写，不再经过自己实例级别的severity阈值。
+// component.go里的componentData用它来实现"单个组件比全局更详细"——组件自己的阈值已经
+// 检查过了，不需要、也不能再被全局Logger的阈值拦第二次。如果装进来的是外部自定义的
+// LoggerV2（没实现这个接口），就没有办法绕过它自己的过滤逻辑，只能退化成普通转发
+type severityBypassLogger interface {
+	logSeverityDepth(depth, lvl int, args ...interface{})
+}
+
+// 包级别的转发函数，统一经过internal/grpclog里的全局Logger
+// 这样Component等上层封装不需要关心当前装的是哪种LoggerV2实现
+func V(l int) bool {
+	return grpclog.Logger.V(l)
+}
+
+func Info(args ...interface{}) {
+	grpclog.Logger.Info(args...)
+}
+
+func Infoln(args ...interface{}) {
+	grpclog.Logger.Infoln(args...)
+}
+
+func Infof(format string, args ...interface{}) {
+	grpclog.Logger.Infof(format, args...)
+}
+
+func Warning(args ...interface{}) {
+	grpclog.Logger.Warning(args...)
+}
+
+func Warningln(args ...interface{}) {
+	grpclog.Logger.Warningln(args...)
+}
+
+func Warningf(format string, args ...interface{}) {
+	grpclog.Logger.Warningf(format, args...)
+}
+
+func Error(args ...interface{}) {
+	grpclog.Logger.Error(args...)
+}
+
+func Errorln(args ...interface{}) {
+	grpclog.Logger.Errorln(args...)
+}
+
+func Errorf(format string, args ...interface{}) {
+	grpclog.Logger.Errorf(format, args...)
+}
+
+func Fatal(args ...interface{}) {
+	grpclog.Logger.Fatal(args...)
+}
+
+func Fatalln(args ...interface{}) {
+	grpclog.Logger.Fatalln(args...)
+}
+
+func Fatalf(format string, args ...interface{}) {
+	grpclog.Logger.Fatalf(format, args...)
+}
+
+// InfoDepth等优先走DepthLoggerV2，这样日志里的file:line指向真正的调用方而不是这里
+func InfoDepth(depth int, args ...interface{}) {
+	if dl, ok := grpclog.Logger.(DepthLoggerV2); ok {
+		dl.InfoDepth(depth+1, args...)
+	} else {
+		grpclog.Logger.Infoln(args...)
+	}
+}
+
+func WarningDepth(depth int, args ...interface{}) {
+	if dl, ok := grpclog.Logger.(DepthLoggerV2); ok {
+		dl.WarningDepth(depth+1, args...)
+	} else {
+		grpclog.Logger.Warningln(args...)
+	}
+}
+
+func ErrorDepth(depth int, args ...interface{}) {
+	if dl, ok := grpclog.Logger.(DepthLoggerV2); ok {
+		dl.ErrorDepth(depth+1, args...)
+	} else {
+		grpclog.Logger.Errorln(args...)
+	}
+}
+
+func FatalDepth(depth int, args ...interface{}) {
+	if dl, ok := grpclog.Logger.(DepthLoggerV2); ok {
+		dl.FatalDepth(depth+1, args...)
+	} else {
+		grpclog.Logger.Fatalln(args...)
+	}
+}
@@ -0,0 +1,236 @@
+/*
+ *
+ * Copyright 2022 gRPC authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package grpclog
+
+import (
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"os"
+	"runtime"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// 开启GRPC_GO_LOG_FORMAT=json后，每一行输出都是一个完整的JSON对象，方便日志采集端直接解析
+type logLine struct {
+	Timestamp time.Time `json:"ts"`
+	Severity  string    `json:"severity"`
+	File      string    `json:"file"`
+	Component string    `json:"component,omitempty"`
+	Msg       string    `json:"msg"`
+}
+
+// droppedLogMessages统计异步模式下，因为队列满而被丢弃的日志条数，可以通过expvar暴露出去观察
+var droppedLogMessages = expvar.NewInt("grpclog_dropped_messages")
+
+// asyncBufSize是异步队列的容量；超过这个容量后，新写入会把最老的一条挤出去（drop-oldest）
+const asyncBufSize = 1024
+
+// structuredLogger把日志格式化为JSON对象，可选地通过一个有界channel异步落盘，
+// 避免loggerT里同步的log.Logger.Print在RPC热路径上阻塞调用方
+// 它同时实现了LoggerV2和DepthLoggerV2，这样调用depth相关的方法时文件名/行号依旧是调用处的
+type structuredLogger struct {
+	// sev是最低展示的severity（infoLog/warningLog/errorLog），
+	// 和loggerT.sev是同一套语义，默认实例里都来自GRPC_GO_LOG_SEVERITY_LEVEL
+	sev int
+	v   int
+
+	async bool
+	ch    chan logLine
+
+	// mu保护w的并发写入；同步模式下调用方各自写w，异步模式下drain goroutine和FatalDepth
+	// （直接在调用方goroutine里写）也可能同时写w，所以write()总是加锁
+	mu sync.Mutex
+	w  writer
+}
+
+// writer只要求一个Write方法（和io.Writer相同），os.Stderr、bytes.Buffer都可以直接传进来
+type writer interface {
+	Write(p []byte) (n int, err error)
+}
+
+// newStructuredLogger根据GRPC_GO_LOG_ASYNC决定是否启动后台goroutine异步drain日志
+func newStructuredLogger(w writer, sev, v int, async bool) *structuredLogger {
+	l := &structuredLogger{sev: sev, v: v, w: w, async: async}
+	if async {
+		l.ch = make(chan logLine, asyncBufSize)
+		go l.drain()
+	}
+	return l
+}
+
+func (l *structuredLogger) drain() {
+	for line := range l.ch {
+		l.write(line)
+	}
+}
+
+func (l *structuredLogger) write(line logLine) {
+	b, err := json.Marshal(line)
+	if err != nil {
+		return
+	}
+	b = append(b, '\n')
+	l.mu.Lock()
+	l.w.Write(b)
+	l.mu.Unlock()
+}
+
+// enqueue在async模式下把日志塞进channel；channel满了就丢弃最老的一条腾出位置，
+// 保证这里永远不会阻塞住调用方（也就是不会拖慢RPC热路径）
+func (l *structuredLogger) enqueue(line logLine) {
+	if !l.async {
+		l.write(line)
+		return
+	}
+	select {
+	case l.ch <- line:
+	default:
+		select {
+		case <-l.ch:
+			droppedLogMessages.Add(1)
+		default:
+		}
+		select {
+		case l.ch <- line:
+		default:
+			droppedLogMessages.Add(1)
+		}
+	}
+}
+
+func callerLine(depth int) string {
+	_, file, line, ok := runtime.Caller(depth + 1)
+	if !ok {
+		return "???"
+	}
+	return file + ":" + strconv.Itoa(line)
+}
+
+func (l *structuredLogger) buildLine(depth int, sev string, msg string) logLine {
+	component, msg := splitComponentPrefix(msg)
+	return logLine{
+		Timestamp: time.Now(),
+		Severity:  sev,
+		File:      callerLine(depth + 1),
+		Component: component,
+		Msg:       msg,
+	}
+}
+
+// logAt先按lvl（infoLog/warningLog/errorLog）和l.sev比较，未达到阈值的消息直接丢弃，
+// 和loggerT.enabled()按loggerT.sev过滤是同一个效果
+func (l *structuredLogger) logAt(depth, lvl int, msg string) {
+	if lvl < l.sev {
+		return
+	}
+	l.enqueue(l.buildLine(depth+1, severityName[lvl], msg))
+}
+
+// Component()给消息加上了"[name]"前缀（fmt.Sprint在两个string之间不加空格，所以通常紧跟正文），
+// 这里把它拆出来单独放进JSON的component字段，前缀后如果有一个空格也一并去掉，
+// 而不是让日志聚合端再去正则解析msg里的前缀
+func splitComponentPrefix(msg string) (component, rest string) {
+	if len(msg) == 0 || msg[0] != '[' {
+		return "", msg
+	}
+	end := -1
+	for i := 1; i < len(msg); i++ {
+		if msg[i] == ']' {
+			end = i
+			break
+		}
+	}
+	if end < 0 {
+		return "", msg
+	}
+	rest = msg[end+1:]
+	if len(rest) > 0 && rest[0] == ' ' {
+		rest = rest[1:]
+	}
+	return msg[1:end], rest
+}
+
+func (l *structuredLogger) Info(args ...interface{})  { l.InfoDepth(1, args...) }
+func (l *structuredLogger) Infoln(args ...interface{}) { l.InfoDepth(1, args...) }
+func (l *structuredLogger) Infof(format string, args ...interface{}) {
+	l.InfoDepth(1, fmt.Sprintf(format, args...))
+}
+
+func (l *structuredLogger) Warning(args ...interface{})  { l.WarningDepth(1, args...) }
+func (l *structuredLogger) Warningln(args ...interface{}) { l.WarningDepth(1, args...) }
+func (l *structuredLogger) Warningf(format string, args ...interface{}) {
+	l.WarningDepth(1, fmt.Sprintf(format, args...))
+}
+
+func (l *structuredLogger) Error(args ...interface{})  { l.ErrorDepth(1, args...) }
+func (l *structuredLogger) Errorln(args ...interface{}) { l.ErrorDepth(1, args...) }
+func (l *structuredLogger) Errorf(format string, args ...interface{}) {
+	l.ErrorDepth(1, fmt.Sprintf(format, args...))
+}
+
+func (l *structuredLogger) Fatal(args ...interface{})  { l.FatalDepth(1, args...) }
+func (l *structuredLogger) Fatalln(args ...interface{}) { l.FatalDepth(1, args...) }
+func (l *structuredLogger) Fatalf(format string, args ...interface{}) {
+	l.FatalDepth(1, fmt.Sprintf(format, args...))
+}
+
+func (l *structuredLogger) InfoDepth(depth int, args ...interface{}) {
+	l.logAt(depth+1, infoLog, fmt.Sprint(args...))
+}
+
+func (l *structuredLogger) WarningDepth(depth int, args ...interface{}) {
+	l.logAt(depth+1, warningLog, fmt.Sprint(args...))
+}
+
+func (l *structuredLogger) ErrorDepth(depth int, args ...interface{}) {
+	l.logAt(depth+1, errorLog, fmt.Sprint(args...))
+}
+
+// FatalDepth故意不走enqueue/channel：如果异步模式下还有其他goroutine在并发写日志，
+// close(l.ch)会让它们的l.ch<-line在关闭的channel上panic。
+// 这里先把channel里已经攒下的日志非阻塞地排空写掉，fatal这一条再同步直接写一次，
+// 两者都不依赖关闭channel，所以不会和仍在运行的生产者产生竞争
+func (l *structuredLogger) FatalDepth(depth int, args ...interface{}) {
+	if l.async {
+	drain:
+		for {
+			select {
+			case line := <-l.ch:
+				l.write(line)
+			default:
+				break drain
+			}
+		}
+	}
+	l.write(l.buildLine(depth+1, severityName[fatalLog], fmt.Sprint(args...)))
+	os.Exit(1)
+}
+
+func (l *structuredLogger) V(lv int) bool {
+	return lv <= l.v
+}
+
+// logSeverityDepth实现severityBypassLogger，绕开l.sev这个实例级别的阈值，
+// 直接按调用方指定的lvl入队；用途和loggerT.logSeverityDepth一致，见那边的注释
+func (l *structuredLogger) logSeverityDepth(depth, lvl int, args ...interface{}) {
+	l.enqueue(l.buildLine(depth+1, severityName[lvl], fmt.Sprint(args...)))
+}
@@ -0,0 +1,81 @@
+/*
+ *
+ * Copyright 2022 gRPC authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package grpclog
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestSplitComponentPrefix(t *testing.T) {
+	tests := []struct {
+		in            string
+		wantComponent string
+		wantRest      string
+	}{
+		{"[transport] connection closed", "transport", "connection closed"},
+		{"no prefix here", "", "no prefix here"},
+		{"[xds]no space", "xds", "no space"},
+	}
+	for _, tt := range tests {
+		component, rest := splitComponentPrefix(tt.in)
+		if component != tt.wantComponent || rest != tt.wantRest {
+			t.Errorf("splitComponentPrefix(%q) = %q, %q; want %q, %q", tt.in, component, rest, tt.wantComponent, tt.wantRest)
+		}
+	}
+}
+
+func TestStructuredLoggerSeverityGate(t *testing.T) {
+	var buf bytes.Buffer
+	l := newStructuredLogger(&buf, warningLog, 0, false)
+
+	l.Info("should be dropped")
+	if buf.Len() != 0 {
+		t.Fatalf("Info() wrote output %q when severity threshold is warningLog, want nothing", buf.String())
+	}
+
+	l.Warning("should be kept")
+	var line logLine
+	if err := json.Unmarshal(bytes.TrimSpace(buf.Bytes()), &line); err != nil {
+		t.Fatalf("output is not valid JSON: %v (%q)", err, buf.String())
+	}
+	if line.Severity != severityName[warningLog] || line.Msg != "should be kept" {
+		t.Errorf("line = %+v, want Severity %q and Msg %q", line, severityName[warningLog], "should be kept")
+	}
+}
+
+func TestStructuredLoggerAsyncEnqueueDoesNotBlock(t *testing.T) {
+	var buf bytes.Buffer
+	l := newStructuredLogger(&buf, infoLog, 0, true)
+
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < asyncBufSize*2; i++ {
+			l.Info("spam")
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("enqueue blocked under a full async buffer instead of dropping the oldest entry")
+	}
+}
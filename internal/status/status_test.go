@@ -0,0 +1,79 @@
+/*
+ *
+ * Copyright 2017 gRPC authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package status
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/ptypes/wrappers"
+
+	"google.golang.org/grpc/codes"
+)
+
+func TestNewAndErr(t *testing.T) {
+	s := New(codes.NotFound, "not found")
+	if s.Code() != codes.NotFound || s.Message() != "not found" {
+		t.Fatalf("New() = {%v, %q}, want {%v, %q}", s.Code(), s.Message(), codes.NotFound, "not found")
+	}
+	if err := s.Err(); err == nil {
+		t.Fatal("Err() = nil for a non-OK status, want non-nil")
+	}
+	if ok := New(codes.OK, "").Err(); ok != nil {
+		t.Fatalf("Err() = %v for an OK status, want nil", ok)
+	}
+}
+
+func TestWithDetailsRoundTrip(t *testing.T) {
+	s := New(codes.InvalidArgument, "bad request")
+	detail := &wrappers.StringValue{Value: "field x is required"}
+	sd, err := s.WithDetails(detail)
+	if err != nil {
+		t.Fatalf("WithDetails() error = %v", err)
+	}
+
+	details := sd.Details()
+	if len(details) != 1 {
+		t.Fatalf("len(Details()) = %d, want 1", len(details))
+	}
+	got, ok := details[0].(*wrappers.StringValue)
+	if !ok {
+		t.Fatalf("Details()[0] has type %T, want *wrappers.StringValue", details[0])
+	}
+	if got.Value != detail.Value {
+		t.Errorf("Details()[0].Value = %q, want %q", got.Value, detail.Value)
+	}
+
+	// The original Status must be unaffected by WithDetails.
+	if len(s.Proto().GetDetails()) != 0 {
+		t.Error("WithDetails mutated the receiver's Proto().Details")
+	}
+}
+
+func TestWithDetailsRejectsOK(t *testing.T) {
+	if _, err := New(codes.OK, "").WithDetails(&wrappers.StringValue{Value: "x"}); err == nil {
+		t.Fatal("WithDetails() on an OK status returned nil error, want an error")
+	}
+}
+
+func TestDetailsOnNilStatus(t *testing.T) {
+	var s *Status
+	if got := s.Details(); got != nil {
+		t.Fatalf("Details() on a nil *Status = %v, want nil", got)
+	}
+}
@@ -29,8 +29,10 @@ package status
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
+	edpb "google.golang.org/genproto/googleapis/rpc/errdetails"
 	spb "google.golang.org/genproto/googleapis/rpc/status"
 
 	"google.golang.org/grpc/codes"
@@ -112,16 +114,40 @@ func Code(err error) codes.Code {
 }
 
 // 集成了常用的Context两种错误情况
+// 这里改用errors.Is而不是直接和context.DeadlineExceeded/context.Canceled比较，
+// 这样经过context.WithCancelCause/WithTimeoutCause或者fmt.Errorf("...: %w", ...)包装过的
+// context错误也能被正确分类，而不是一律退化成codes.Unknown
 func FromContextError(err error) *Status {
-	switch err {
-	case nil:
+	if err == nil {
 		return nil
-	case context.DeadlineExceeded:
+	}
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
 		return New(codes.DeadlineExceeded, err.Error())
-	case context.Canceled:
+	case errors.Is(err, context.Canceled):
 		return New(codes.Canceled, err.Error())
 	default:
 		return New(codes.Unknown, err.Error())
 	}
 }
 
+// FromContext一次性检查ctx.Err()和context.Cause(ctx)：如果ctx是被
+// context.WithCancelCause/WithTimeoutCause取消的，底层真正的cause会作为DebugInfo detail
+// 附加在返回的Status上，避免这条信息在层层%w包装之后彻底丢失
+func FromContext(ctx context.Context) *Status {
+	err := ctx.Err()
+	if err == nil {
+		return nil
+	}
+	s := FromContextError(err)
+	cause := context.Cause(ctx)
+	if cause == nil || cause == err {
+		return s
+	}
+	sd, detailErr := s.WithDetails(&edpb.DebugInfo{Detail: cause.Error()})
+	if detailErr != nil {
+		return s
+	}
+	return sd
+}
+
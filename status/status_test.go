@@ -0,0 +1,93 @@
+/*
+ *
+ * Copyright 2017 gRPC authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package status
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	edpb "google.golang.org/genproto/googleapis/rpc/errdetails"
+
+	"google.golang.org/grpc/codes"
+)
+
+func TestFromContextErrorWrapped(t *testing.T) {
+	wrapped := fmt.Errorf("middleware: %w", context.Canceled)
+	s := FromContextError(wrapped)
+	if s.Code() != codes.Canceled {
+		t.Fatalf("FromContextError(wrapped Canceled) code = %v, want %v", s.Code(), codes.Canceled)
+	}
+
+	wrapped = fmt.Errorf("middleware: %w", context.DeadlineExceeded)
+	s = FromContextError(wrapped)
+	if s.Code() != codes.DeadlineExceeded {
+		t.Fatalf("FromContextError(wrapped DeadlineExceeded) code = %v, want %v", s.Code(), codes.DeadlineExceeded)
+	}
+}
+
+func TestFromContextErrorUnrelated(t *testing.T) {
+	s := FromContextError(errors.New("boom"))
+	if s.Code() != codes.Unknown {
+		t.Fatalf("FromContextError(unrelated) code = %v, want %v", s.Code(), codes.Unknown)
+	}
+}
+
+func TestFromContextNil(t *testing.T) {
+	if s := FromContext(context.Background()); s != nil {
+		t.Fatalf("FromContext(not-done ctx) = %v, want nil", s)
+	}
+}
+
+func TestFromContextAttachesCauseAsDebugInfo(t *testing.T) {
+	cause := errors.New("upstream deadline hit first")
+	ctx, cancel := context.WithCancelCause(context.Background())
+	cancel(cause)
+
+	s := FromContext(ctx)
+	if s.Code() != codes.Canceled {
+		t.Fatalf("FromContext() code = %v, want %v", s.Code(), codes.Canceled)
+	}
+
+	details := s.Details()
+	if len(details) != 1 {
+		t.Fatalf("len(Details()) = %d, want 1", len(details))
+	}
+	di, ok := details[0].(*edpb.DebugInfo)
+	if !ok {
+		t.Fatalf("Details()[0] has type %T, want *edpb.DebugInfo", details[0])
+	}
+	if di.Detail != cause.Error() {
+		t.Errorf("DebugInfo.Detail = %q, want %q", di.Detail, cause.Error())
+	}
+}
+
+func TestFromContextNoCauseNoDetails(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	s := FromContext(ctx)
+	if s.Code() != codes.Canceled {
+		t.Fatalf("FromContext() code = %v, want %v", s.Code(), codes.Canceled)
+	}
+	if got := s.Details(); len(got) != 0 {
+		t.Errorf("Details() = %v, want empty (no explicit cause set)", got)
+	}
+}